@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+
+	"ecs-local/pkg/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the ecs-local config file",
+}
+
+var configInitCmd = &cobra.Command{
+	Use:     "init",
+	Short:   "Write the current flags, on top of the defaults, to a config file",
+	Args:    cobra.NoArgs,
+	Example: "ecs-local config init -t stage-accounts -p stage",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.Write(cfgFile); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(exitCodeError)
+		}
+		fmt.Println("Config saved")
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the fully resolved configuration",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(nil)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(exitCodeError)
+		}
+
+		out, err := yaml.Marshal(cfg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(exitCodeError)
+		}
+		fmt.Print(string(out))
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the resolved configuration without making any AWS call",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(nil)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(exitCodeError)
+		}
+
+		if err := config.Validate(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(exitCodeError)
+		}
+		fmt.Println("config is valid")
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configInitCmd, configShowCmd, configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"ecs-local/pkg/awsx"
+	"ecs-local/pkg/runner"
+)
+
+var (
+	execAction    string
+	execContainer string
+)
+
+var execCmd = &cobra.Command{
+	Use:     "exec <taskdef>",
+	Short:   "Attach to the primary container from a prior `run --detach`",
+	Args:    cobra.ExactArgs(1),
+	Example: "ecs-local exec stage-accounts",
+	Run:     runExec,
+}
+
+func init() {
+	execCmd.Flags().StringVarP(&execAction, "action", "a", "", "command to run inside the container (default: an interactive shell)")
+	execCmd.Flags().StringVarP(&execContainer, "container", "n", "", "container to exec into (default: the essential container, or the first one)")
+
+	rootCmd.AddCommand(execCmd)
+}
+
+func runExec(cmd *cobra.Command, args []string) {
+	cfg, err := loadConfig(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeError)
+	}
+	if execContainer != "" {
+		cfg.Container = execContainer
+	}
+
+	sess, err := awsx.NewSession(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeAWSError)
+	}
+
+	task, err := awsx.DescribeTaskDefinition(sess, cfg.TaskDef)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeAWSError)
+	}
+
+	primary, err := runner.SelectPrimaryContainer(task.ContainerDefinitions, cfg.Container)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeFlagParseError)
+	}
+
+	name := runner.ContainerName(*task.TaskDefinitionArn, *primary.Name)
+
+	command := []string{"sh"}
+	if execAction != "" {
+		command = strings.Split(execAction, " ")
+	}
+
+	client, err := runner.NewDockerClient(cfg.DockerHost)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeDockerError)
+	}
+
+	if err := runner.ExecInto(client, name, command); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeDockerError)
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"ecs-local/pkg/awsx"
+	"ecs-local/pkg/ecrauth"
+)
+
+var loginCmd = &cobra.Command{
+	Use:     "login",
+	Short:   "Print a `docker login` command for this account's ECR registry",
+	Args:    cobra.NoArgs,
+	Example: "eval $(ecs-local login -p stage)",
+	Run:     runLogin,
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+}
+
+func runLogin(cmd *cobra.Command, args []string) {
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeError)
+	}
+
+	sess, err := awsx.NewSession(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeAWSError)
+	}
+
+	auth, err := ecrauth.Login(sess)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeDockerError)
+	}
+
+	fmt.Printf("docker login --username %s --password %s %s\n", auth.Username, auth.Password, auth.ServerAddress)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"ecs-local/pkg/awsx"
+	"ecs-local/pkg/config"
+	"ecs-local/pkg/ecrauth"
+	"ecs-local/pkg/runner"
+)
+
+var pullCmd = &cobra.Command{
+	Use:     "pull [taskdef]",
+	Short:   "Fetch every image in a task definition, without starting any containers",
+	Args:    cobra.MaximumNArgs(1),
+	Example: "ecs-local pull stage-accounts",
+	Run:     runPull,
+}
+
+func init() {
+	rootCmd.AddCommand(pullCmd)
+}
+
+func runPull(cmd *cobra.Command, args []string) {
+	cfg, err := loadConfig(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeError)
+	}
+
+	if err := config.Validate(cfg); err != nil {
+		fmt.Println(err.Error())
+		cmd.Help()
+		os.Exit(exitCodeOk)
+	}
+
+	sess, err := awsx.NewSession(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeAWSError)
+	}
+
+	task, err := awsx.DescribeTaskDefinition(sess, cfg.TaskDef)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeAWSError)
+	}
+
+	auth, err := ecrauth.Login(sess)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeDockerError)
+	}
+
+	client, err := runner.NewDockerClient(cfg.DockerHost)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeDockerError)
+	}
+
+	if err := runner.Pull(client, task, auth); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeDockerError)
+	}
+}
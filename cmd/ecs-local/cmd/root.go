@@ -0,0 +1,87 @@
+// Package cmd implements ecs-local's Cobra command surface: run, pull,
+// login, exec, config and completion.
+package cmd
+
+import (
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ecs-local/pkg/awsx"
+	"ecs-local/pkg/config"
+)
+
+const (
+	exitCodeOk             int = 0
+	exitCodeError          int = 1
+	exitCodeDockerError    int = 2
+	exitCodeFlagParseError     = 10 + iota
+	exitCodeAWSError
+)
+
+var cfgFile string
+
+var rootCmd = &cobra.Command{
+	Use:     "ecs-local",
+	Version: "v0.3.0",
+	Example: "ecs-local run -t stage-accounts -m src:dest -c ecs-local-config.yaml -a 'bundle exec rails c'",
+}
+
+// Execute runs the root command.
+func Execute() error {
+	return rootCmd.Execute()
+}
+
+func init() {
+	cobra.OnInitialize(initLogging)
+
+	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file to read from, or write to with `config init` (default is ecs-local-config.yaml; when reading, the XDG and /etc/ecs-local config locations are searched too)")
+	rootCmd.PersistentFlags().StringP("profile", "p", "", "AWS profile")
+	rootCmd.PersistentFlags().StringP("region", "r", "", "AWS region")
+	rootCmd.PersistentFlags().StringP("taskdef", "t", "", "task definition")
+	rootCmd.PersistentFlags().String("docker-host", "", "Docker daemon endpoint, e.g. tcp://localhost:2375 or npipe:////./pipe/docker_engine (default: same resolution as the docker CLI's DOCKER_HOST)")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().Bool("redact-secrets", false, "keep values resolved from ECS Secrets/EnvironmentFiles out of --verbose debug logs")
+
+	viper.BindPFlags(rootCmd.PersistentFlags())
+	config.SetDefaults()
+}
+
+func initLogging() {
+	logrus.SetLevel(logrus.ErrorLevel)
+	if viper.GetBool("verbose") {
+		logrus.SetLevel(logrus.DebugLevel)
+	}
+}
+
+// loadConfig resolves the layered config (flag > env > file > default),
+// then applies a positional taskdef argument, if any, as the final override.
+func loadConfig(args []string) (*config.Config, error) {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 {
+		cfg.TaskDef = args[0]
+	}
+	return cfg, nil
+}
+
+// assumeTaskRole assumes task's TaskRoleArn, if it has one, logging and
+// continuing without credentials on failure rather than failing the run.
+func assumeTaskRole(sess *session.Session, task *ecs.TaskDefinition) *sts.Credentials {
+	if task.TaskRoleArn == nil {
+		return nil
+	}
+	creds, err := awsx.AssumeTaskRole(sess, *task.TaskRoleArn)
+	if err != nil {
+		logrus.Debugf("Unable to assume role %s", *task.TaskRoleArn)
+		logrus.Debugf("%s", err.Error())
+		return nil
+	}
+	logrus.Debugf("Successfully assumed container role %s", *task.TaskRoleArn)
+	return creds
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"ecs-local/pkg/awsx"
+	"ecs-local/pkg/config"
+	"ecs-local/pkg/ecrauth"
+	"ecs-local/pkg/runner"
+)
+
+var (
+	runAction    string
+	runContainer string
+	runMounts    []string
+	runEnvs      []string
+	runNoPull    bool
+	runPullOnly  bool
+	runDetach    bool
+)
+
+var runCmd = &cobra.Command{
+	Use:     "run [taskdef]",
+	Short:   "Run a task definition's containers locally and exec into the primary container",
+	Args:    cobra.MaximumNArgs(1),
+	Example: "ecs-local run -t stage-accounts -a 'bundle exec rails c'",
+	Run:     runRun,
+}
+
+func init() {
+	runCmd.Flags().StringVarP(&runAction, "action", "a", "", "command/action to execute in the primary container")
+	runCmd.Flags().StringVarP(&runContainer, "container", "n", "", "primary container to exec into (default: the essential container, or the first one)")
+	runCmd.Flags().StringSliceVarP(&runMounts, "mounts", "m", []string{}, "mounts src:dest")
+	runCmd.Flags().StringSliceVarP(&runEnvs, "envs", "e", []string{}, "Env variables key=value")
+	runCmd.Flags().BoolVar(&runNoPull, "no-pull", false, "skip pulling images, assuming they're already present locally")
+	runCmd.Flags().BoolVar(&runPullOnly, "pull-only", false, "only pull images, don't start any containers")
+	runCmd.Flags().BoolVar(&runDetach, "detach", false, "start the primary container in the background instead of exec'ing into it")
+
+	rootCmd.AddCommand(runCmd)
+}
+
+func runRun(cmd *cobra.Command, args []string) {
+	cfg, err := loadConfig(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeError)
+	}
+	if runAction != "" {
+		cfg.Action = runAction
+	}
+	if runContainer != "" {
+		cfg.Container = runContainer
+	}
+	cfg.Mounts = append(cfg.Mounts, runMounts...)
+	cfg.Envs = append(cfg.Envs, runEnvs...)
+
+	if err := config.Validate(cfg); err != nil {
+		fmt.Println(err.Error())
+		cmd.Help()
+		os.Exit(exitCodeOk)
+	}
+
+	sess, err := awsx.NewSession(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeAWSError)
+	}
+
+	task, err := awsx.DescribeTaskDefinition(sess, cfg.TaskDef)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeAWSError)
+	}
+
+	client, err := runner.NewDockerClient(cfg.DockerHost)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeDockerError)
+	}
+
+	auth, err := ecrauth.Login(sess)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeDockerError)
+	}
+
+	if runPullOnly {
+		if err := runner.Pull(client, task, auth); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(exitCodeDockerError)
+		}
+		return
+	}
+
+	if err := awsx.ResolveContainerSecrets(sess, task, cfg.RedactSecrets); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeAWSError)
+	}
+
+	opts := runner.Options{
+		Container: cfg.Container,
+		Action:    cfg.Action,
+		Mounts:    cfg.Mounts,
+		Envs:      cfg.Envs,
+		NoPull:    runNoPull,
+		Detach:    runDetach,
+	}
+	opts.TaskRoleCredentials = assumeTaskRole(sess, task)
+
+	if err := runner.Run(client, task, auth, opts); err != nil {
+		if exitErr, ok := err.(*runner.ExitError); ok {
+			os.Exit(exitErr.Code)
+		}
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeDockerError)
+	}
+}
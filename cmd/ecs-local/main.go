@@ -0,0 +1,16 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"ecs-local/cmd/ecs-local/cmd"
+)
+
+func main() {
+	if err := cmd.Execute(); err != nil {
+		logrus.Debugf("\n%+v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,230 @@
+package awsx
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/sirupsen/logrus"
+)
+
+// CredentialCacheProvider wraps another credentials.Credentials, persisting
+// whatever session credentials it returns to ~/.aws/cli/cache, under the
+// AWS CLI's own `assume-role` cache file shape. That means a profile's MFA
+// prompt (the slow, manual part of session.Options.AssumeRoleTokenProvider)
+// only has to happen once per session lifetime, not once per ecs-local
+// invocation.
+//
+// This is a private cache, not an AWS CLI-interoperable one: the cache key
+// (see cacheKey) isn't derived the way botocore derives its own, so ecs-local
+// and the AWS CLI can't read each other's cache entries - they just happen to
+// live in the same directory.
+//
+// SSO, web identity (AWS_WEB_IDENTITY_TOKEN_FILE, for EKS/IRSA) and EC2
+// IMDSv2 credentials don't need this: NewSession already resolves all three
+// for free, because SharedConfigState is enabled, which makes the SDK's own
+// default provider chain (chain.go in aws-sdk-go) walk sso_*, web identity
+// and EC2 instance role providers before falling back to a plain profile.
+type CredentialCacheProvider struct {
+	Creds   *credentials.Credentials
+	Profile string
+
+	cached *cacheFile
+}
+
+// Retrieve satisfies credentials.Provider. It serves a still-valid cached
+// session from disk before falling back to the wrapped credentials (which is
+// what prompts for MFA, if the profile needs it), caching whatever that
+// returns for next time.
+func (p *CredentialCacheProvider) Retrieve() (credentials.Value, error) {
+	key := p.cacheKey()
+
+	if cf, err := readCacheFile(key); err == nil && cf.Credentials.Expiration.After(time.Now()) {
+		logrus.Debugf("Using cached credentials for profile %q", p.Profile)
+		p.cached = cf
+		return cf.value(), nil
+	}
+
+	value, err := p.Creds.Get()
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	expiration, err := p.Creds.ExpiresAt()
+	if err != nil {
+		logrus.Debugf("Provider %s doesn't expose an expiration; not caching", value.ProviderName)
+		return value, nil
+	}
+
+	if cf := newCacheFile(value, expiration); cf != nil {
+		p.cached = cf
+		if err := writeCacheFile(key, cf); err != nil {
+			logrus.Debugf("Unable to cache credentials for profile %q: %s", p.Profile, err.Error())
+		}
+	}
+
+	return value, nil
+}
+
+// IsExpired satisfies credentials.Provider.
+func (p *CredentialCacheProvider) IsExpired() bool {
+	if p.cached != nil {
+		return !p.cached.Credentials.Expiration.After(time.Now())
+	}
+	return p.Creds.IsExpired()
+}
+
+// cacheKey identifies this profile's cached session, mixing in its assumed
+// role and MFA device (if any) so distinct roles/devices sharing a profile
+// don't collide. Unlike botocore's cache key (a hash of the actual
+// assume-role request parameters), this is a home-grown derivation private
+// to ecs-local - see the CredentialCacheProvider doc comment.
+func (p *CredentialCacheProvider) cacheKey() string {
+	roleArn, mfaSerial := profileRoleAndMFA(p.Profile)
+
+	h := sha1.New()
+	fmt.Fprintf(h, "%s|%s|%s", p.Profile, roleArn, mfaSerial)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheFile mirrors the AWS CLI's ~/.aws/cli/cache/<key>.json format.
+type cacheFile struct {
+	Credentials struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		SessionToken    string    `json:"SessionToken"`
+		Expiration      time.Time `json:"Expiration"`
+	} `json:"Credentials"`
+}
+
+func (cf *cacheFile) value() credentials.Value {
+	return credentials.Value{
+		AccessKeyID:     cf.Credentials.AccessKeyID,
+		SecretAccessKey: cf.Credentials.SecretAccessKey,
+		SessionToken:    cf.Credentials.SessionToken,
+		ProviderName:    "CredentialCacheProvider",
+	}
+}
+
+// newCacheFile builds a cacheFile from a retrieved Value and its real
+// expiration, as reported by the wrapped provider's own ExpiresAt() - not
+// assumed, since only the assumed-role/MFA path actually honors
+// stscreds.DefaultDuration; SSO, web identity and EC2 role credentials each
+// carry their own, usually shorter, lifetime. Long-lived credentials (a
+// plain IAM user, no session token) aren't worth caching.
+func newCacheFile(value credentials.Value, expiration time.Time) *cacheFile {
+	if value.SessionToken == "" {
+		return nil
+	}
+	cf := &cacheFile{}
+	cf.Credentials.AccessKeyID = value.AccessKeyID
+	cf.Credentials.SecretAccessKey = value.SecretAccessKey
+	cf.Credentials.SessionToken = value.SessionToken
+	cf.Credentials.Expiration = expiration
+	return cf
+}
+
+func cacheFilePath(key string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".aws", "cli", "cache", key+".json"), nil
+}
+
+func readCacheFile(key string) (*cacheFile, error) {
+	path, err := cacheFilePath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cf cacheFile
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return nil, err
+	}
+	return &cf, nil
+}
+
+func writeCacheFile(key string, cf *cacheFile) error {
+	path, err := cacheFilePath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cf, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// profileRoleAndMFA reads role_arn and mfa_serial for profile out of the AWS
+// shared config file (~/.aws/config, or AWS_CONFIG_FILE), the two settings
+// that make a profile's session worth caching. Either may come back empty.
+func profileRoleAndMFA(profile string) (roleArn, mfaSerial string) {
+	path := os.Getenv("AWS_CONFIG_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		path = filepath.Join(home, ".aws", "config")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	wantSection := "profile " + profile
+	if profile == "default" {
+		wantSection = "default"
+	}
+
+	var inSection bool
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.TrimSpace(line[1:len(line)-1]) == wantSection
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(parts[0]) {
+		case "role_arn":
+			roleArn = strings.TrimSpace(parts[1])
+		case "mfa_serial":
+			mfaSerial = strings.TrimSpace(parts[1])
+		}
+	}
+
+	return roleArn, mfaSerial
+}
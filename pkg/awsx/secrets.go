@@ -0,0 +1,227 @@
+package awsx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/sirupsen/logrus"
+)
+
+// ssmGetParametersBatchSize is the most names ssm.GetParameters accepts in a
+// single call.
+const ssmGetParametersBatchSize = 10
+
+// ResolveContainerSecrets resolves every container definition's Secrets (SSM
+// Parameter Store or Secrets Manager references) and EnvironmentFiles
+// (S3-hosted .env files) into plain KeyValuePairs, appended to each
+// container's own Environment. The runner package only ever reads
+// Environment, so it never needs to know a value came from somewhere else.
+//
+// redactInLogs keeps resolved values out of debug logging; the values
+// themselves are always injected into the container regardless.
+func ResolveContainerSecrets(sess *session.Session, task *ecs.TaskDefinition, redactInLogs bool) error {
+	for _, cd := range task.ContainerDefinitions {
+		secretsEnv, err := resolveSecrets(sess, cd.Secrets, redactInLogs)
+		if err != nil {
+			return fmt.Errorf("resolving secrets for container %s: %w", *cd.Name, err)
+		}
+		cd.Environment = append(cd.Environment, secretsEnv...)
+
+		fileEnv, err := resolveEnvironmentFiles(sess, cd.EnvironmentFiles, redactInLogs)
+		if err != nil {
+			return fmt.Errorf("resolving environment files for container %s: %w", *cd.Name, err)
+		}
+		cd.Environment = append(cd.Environment, fileEnv...)
+	}
+	return nil
+}
+
+// resolveSecrets fetches every Secret's value, routing each to SSM Parameter
+// Store or Secrets Manager by inspecting its ValueFrom ARN's service.
+func resolveSecrets(sess *session.Session, secrets []*ecs.Secret, redactInLogs bool) ([]*ecs.KeyValuePair, error) {
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+
+	var ssmSecrets, smSecrets []*ecs.Secret
+	for _, s := range secrets {
+		parsed, err := arn.Parse(*s.ValueFrom)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: invalid ARN %q: %w", *s.Name, *s.ValueFrom, err)
+		}
+		switch parsed.Service {
+		case "ssm":
+			ssmSecrets = append(ssmSecrets, s)
+		case "secretsmanager":
+			smSecrets = append(smSecrets, s)
+		default:
+			return nil, fmt.Errorf("secret %s: unsupported secret ARN service %q", *s.Name, parsed.Service)
+		}
+	}
+
+	var env []*ecs.KeyValuePair
+
+	if len(ssmSecrets) > 0 {
+		values, err := resolveSSMSecrets(sess, ssmSecrets)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, values...)
+	}
+
+	for _, s := range smSecrets {
+		value, err := resolveSecretsManagerSecret(sess, s)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, value)
+	}
+
+	logResolvedEnv(env, redactInLogs, "secret")
+	return env, nil
+}
+
+// resolveSSMSecrets fetches secrets' ValueFrom parameters from SSM Parameter
+// Store, batching GetParameters calls to ssmGetParametersBatchSize names.
+func resolveSSMSecrets(sess *session.Session, secrets []*ecs.Secret) ([]*ecs.KeyValuePair, error) {
+	nameFor := map[string]string{} // parameter name/ARN -> secret Name
+	var params []*string
+	for _, s := range secrets {
+		nameFor[*s.ValueFrom] = *s.Name
+		params = append(params, s.ValueFrom)
+	}
+
+	svc := ssm.New(sess)
+	var env []*ecs.KeyValuePair
+	for i := 0; i < len(params); i += ssmGetParametersBatchSize {
+		end := i + ssmGetParametersBatchSize
+		if end > len(params) {
+			end = len(params)
+		}
+
+		out, err := svc.GetParameters(&ssm.GetParametersInput{
+			Names:          params[i:end],
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(out.InvalidParameters) > 0 {
+			return nil, fmt.Errorf("invalid SSM parameters: %s", strings.Join(aws.StringValueSlice(out.InvalidParameters), ", "))
+		}
+
+		for _, p := range out.Parameters {
+			env = append(env, &ecs.KeyValuePair{
+				Name:  aws.String(nameFor[*p.Name]),
+				Value: p.Value,
+			})
+		}
+	}
+	return env, nil
+}
+
+// resolveSecretsManagerSecret fetches s's ValueFrom secret from Secrets
+// Manager.
+func resolveSecretsManagerSecret(sess *session.Session, s *ecs.Secret) (*ecs.KeyValuePair, error) {
+	svc := secretsmanager.New(sess)
+	out, err := svc.GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: s.ValueFrom,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.SecretString == nil {
+		return nil, fmt.Errorf("secret %s: binary secret values aren't supported", *s.Name)
+	}
+	return &ecs.KeyValuePair{Name: s.Name, Value: out.SecretString}, nil
+}
+
+// resolveEnvironmentFiles downloads every EnvironmentFile from S3 and parses
+// its KEY=VALUE lines.
+func resolveEnvironmentFiles(sess *session.Session, files []*ecs.EnvironmentFile, redactInLogs bool) ([]*ecs.KeyValuePair, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	svc := s3.New(sess)
+	var env []*ecs.KeyValuePair
+	for _, f := range files {
+		if f.Type == nil || *f.Type != "s3" {
+			return nil, fmt.Errorf("environment file %s: unsupported type %q", *f.Value, aws.StringValue(f.Type))
+		}
+
+		bucket, key, err := s3BucketAndKey(*f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("environment file %s: %w", *f.Value, err)
+		}
+
+		out, err := svc.GetObject(&s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		if err != nil {
+			return nil, fmt.Errorf("downloading s3://%s/%s: %w", bucket, key, err)
+		}
+
+		pairs, err := parseEnvFile(out.Body)
+		out.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing s3://%s/%s: %w", bucket, key, err)
+		}
+		env = append(env, pairs...)
+	}
+
+	logResolvedEnv(env, redactInLogs, "environment file variable")
+	return env, nil
+}
+
+// s3BucketAndKey splits an S3 object ARN (arn:aws:s3:::bucket/key) into its
+// bucket and key.
+func s3BucketAndKey(objectArn string) (bucket, key string, err error) {
+	parsed, err := arn.Parse(objectArn)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid ARN: %w", err)
+	}
+	parts := strings.SplitN(parsed.Resource, "/", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expected an S3 object ARN (arn:aws:s3:::bucket/key)")
+	}
+	return parts[0], parts[1], nil
+}
+
+// parseEnvFile reads KEY=VALUE lines from r, skipping blank lines and those
+// starting with #, the same rules docker run --env-file uses.
+func parseEnvFile(r io.Reader) ([]*ecs.KeyValuePair, error) {
+	var pairs []*ecs.KeyValuePair
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		pairs = append(pairs, &ecs.KeyValuePair{Name: aws.String(parts[0]), Value: aws.String(parts[1])})
+	}
+	return pairs, scanner.Err()
+}
+
+// logResolvedEnv debug-logs each resolved pair's name, and its value too
+// unless redactInLogs is set.
+func logResolvedEnv(env []*ecs.KeyValuePair, redactInLogs bool, kind string) {
+	for _, e := range env {
+		if redactInLogs {
+			logrus.Debugf("Resolved %s %s=***", kind, *e.Name)
+		} else {
+			logrus.Debugf("Resolved %s %s=%s", kind, *e.Name, *e.Value)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package awsx
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeAWSSession points an SDK session at srv instead of the real service
+// endpoint, with throwaway static credentials - enough to exercise request
+// building and response parsing without a real AWS account or network.
+func fakeAWSSession(t *testing.T, srv *httptest.Server) *session.Session {
+	t.Helper()
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(srv.URL),
+		DisableSSL:  aws.Bool(true),
+		Credentials: credentials.NewStaticCredentials("akid", "secret", ""),
+	})
+	if err != nil {
+		t.Fatalf("NewSession: %s", err)
+	}
+	return sess
+}
+
+func jsonServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(body))
+	}))
+}
+
+func secret(name, valueFrom string) *ecs.Secret {
+	return &ecs.Secret{Name: aws.String(name), ValueFrom: aws.String(valueFrom)}
+}
+
+func TestResolveSecretsInvalidARN(t *testing.T) {
+	_, err := resolveSecrets(nil, []*ecs.Secret{secret("PASSWORD", "not-an-arn")}, false)
+	if err == nil || !strings.Contains(err.Error(), "invalid ARN") {
+		t.Fatalf("expected an invalid ARN error, got %v", err)
+	}
+}
+
+func TestResolveSecretsUnsupportedService(t *testing.T) {
+	_, err := resolveSecrets(nil, []*ecs.Secret{secret("PASSWORD", "arn:aws:kms:us-east-1:123456789012:key/abc")}, false)
+	if err == nil || !strings.Contains(err.Error(), "unsupported secret ARN service") {
+		t.Fatalf("expected an unsupported service error, got %v", err)
+	}
+}
+
+func TestResolveSecretsDispatchesSSM(t *testing.T) {
+	valueFrom := "arn:aws:ssm:us-east-1:123456789012:parameter/app/password"
+	resp, _ := json.Marshal(map[string]interface{}{
+		"Parameters": []map[string]interface{}{
+			{"Name": valueFrom, "Value": "hunter2"},
+		},
+		"InvalidParameters": []string{},
+	})
+	srv := jsonServer(t, string(resp))
+	defer srv.Close()
+
+	env, err := resolveSecrets(fakeAWSSession(t, srv), []*ecs.Secret{
+		secret("PASSWORD", valueFrom),
+	}, false)
+	if err != nil {
+		t.Fatalf("resolveSecrets: %s", err)
+	}
+	if len(env) != 1 || *env[0].Name != "PASSWORD" || *env[0].Value != "hunter2" {
+		t.Fatalf("got %v", env)
+	}
+}
+
+func TestResolveSecretsDispatchesSecretsManager(t *testing.T) {
+	resp, _ := json.Marshal(map[string]interface{}{
+		"SecretString": "hunter2",
+	})
+	srv := jsonServer(t, string(resp))
+	defer srv.Close()
+
+	env, err := resolveSecrets(fakeAWSSession(t, srv), []*ecs.Secret{
+		secret("PASSWORD", "arn:aws:secretsmanager:us-east-1:123456789012:secret:app/password-AbCdEf"),
+	}, false)
+	if err != nil {
+		t.Fatalf("resolveSecrets: %s", err)
+	}
+	if len(env) != 1 || *env[0].Name != "PASSWORD" || *env[0].Value != "hunter2" {
+		t.Fatalf("got %v", env)
+	}
+}
+
+func TestS3BucketAndKey(t *testing.T) {
+	bucket, key, err := s3BucketAndKey("arn:aws:s3:::my-bucket/path/to/file.env")
+	if err != nil {
+		t.Fatalf("s3BucketAndKey: %s", err)
+	}
+	if bucket != "my-bucket" || key != "path/to/file.env" {
+		t.Errorf("got bucket=%q key=%q", bucket, key)
+	}
+}
+
+func TestS3BucketAndKeyNoKey(t *testing.T) {
+	if _, _, err := s3BucketAndKey("arn:aws:s3:::my-bucket"); err == nil {
+		t.Fatal("expected an error for an ARN with no object key")
+	}
+}
+
+func TestS3BucketAndKeyInvalidARN(t *testing.T) {
+	if _, _, err := s3BucketAndKey("not-an-arn"); err == nil {
+		t.Fatal("expected an error for an invalid ARN")
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	r := strings.NewReader("# a comment\n\nFOO=bar\nBAZ=qux=quux\n")
+	pairs, err := parseEnvFile(r)
+	if err != nil {
+		t.Fatalf("parseEnvFile: %s", err)
+	}
+	if len(pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %d", len(pairs))
+	}
+	if *pairs[0].Name != "FOO" || *pairs[0].Value != "bar" {
+		t.Errorf("got %s=%s", *pairs[0].Name, *pairs[0].Value)
+	}
+	if *pairs[1].Name != "BAZ" || *pairs[1].Value != "qux=quux" {
+		t.Errorf("got %s=%s", *pairs[1].Name, *pairs[1].Value)
+	}
+}
+
+func TestParseEnvFileSkipsMalformedLines(t *testing.T) {
+	pairs, err := parseEnvFile(strings.NewReader("not-a-pair\nFOO=bar\n"))
+	if err != nil {
+		t.Fatalf("parseEnvFile: %s", err)
+	}
+	if len(pairs) != 1 || *pairs[0].Name != "FOO" {
+		t.Fatalf("got %v", pairs)
+	}
+}
+
+func TestLogResolvedEnvRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	origOut, origLevel := logrus.StandardLogger().Out, logrus.GetLevel()
+	logrus.SetOutput(&buf)
+	logrus.SetLevel(logrus.DebugLevel)
+	defer func() {
+		logrus.SetOutput(origOut)
+		logrus.SetLevel(origLevel)
+	}()
+
+	env := []*ecs.KeyValuePair{{Name: aws.String("PASSWORD"), Value: aws.String("hunter2")}}
+	logResolvedEnv(env, true, "secret")
+
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("expected the value to be redacted, got log output: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "PASSWORD=***") {
+		t.Errorf("expected a redacted PASSWORD entry, got: %s", buf.String())
+	}
+}
+
+func TestLogResolvedEnvUnredacted(t *testing.T) {
+	var buf bytes.Buffer
+	origOut, origLevel := logrus.StandardLogger().Out, logrus.GetLevel()
+	logrus.SetOutput(&buf)
+	logrus.SetLevel(logrus.DebugLevel)
+	defer func() {
+		logrus.SetOutput(origOut)
+		logrus.SetLevel(origLevel)
+	}()
+
+	env := []*ecs.KeyValuePair{{Name: aws.String("PASSWORD"), Value: aws.String("hunter2")}}
+	logResolvedEnv(env, false, "secret")
+
+	if !strings.Contains(buf.String(), "PASSWORD=hunter2") {
+		t.Errorf("expected the unredacted value in the log, got: %s", buf.String())
+	}
+}
@@ -0,0 +1,93 @@
+// Package awsx wraps the AWS SDK calls ecs-local needs: building a session
+// with the right region/profile/credentials, fetching task definitions, and
+// assuming a task's IAM role.
+package awsx
+
+import (
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/sirupsen/logrus"
+
+	"ecs-local/pkg/config"
+)
+
+// NewSession builds an AWS session for cfg.Region/cfg.Profile, preferring
+// AWS_REGION/AWS_PROFILE from the environment when set, and wraps its
+// credentials in a CredentialCacheProvider.
+func NewSession(cfg *config.Config) (*session.Session, error) {
+	region := cfg.Region
+	if envRegion, present := os.LookupEnv("AWS_REGION"); present {
+		region = envRegion
+		logrus.Debugf("Using AWS_REGION from ENV")
+	}
+
+	profile := cfg.Profile
+	if envProfile, present := os.LookupEnv("AWS_PROFILE"); present {
+		profile = envProfile
+		logrus.Debugf("Using AWS_PROFILE from ENV")
+	}
+
+	logrus.Debugf("Using AWS region %q", region)
+	logrus.Debugf("Using AWS profile %q", profile)
+
+	// override default sts session duration
+	stscreds.DefaultDuration = time.Duration(1) * time.Hour
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		AssumeRoleTokenProvider: stscreds.StdinTokenProvider,
+		SharedConfigState:       session.SharedConfigEnable,
+		Profile:                 profile,
+		Config:                  aws.Config{Region: aws.String(region)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sess.Config.Credentials = credentials.NewCredentials(&CredentialCacheProvider{
+		Creds:   sess.Config.Credentials,
+		Profile: profile,
+	})
+
+	if logrus.GetLevel() == logrus.DebugLevel {
+		creds, _ := sess.Config.Credentials.Get()
+		logrus.Debugf("Credential provider is %s", creds.ProviderName)
+	}
+
+	return sess, nil
+}
+
+// DescribeTaskDefinition fetches the named (or ARN'd) task definition.
+func DescribeTaskDefinition(sess *session.Session, taskDefinitionName string) (*ecs.TaskDefinition, error) {
+	svc := ecs.New(sess)
+	resp, err := svc.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String(taskDefinitionName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Found task %s", *resp.TaskDefinition.TaskDefinitionArn)
+	return resp.TaskDefinition, nil
+}
+
+// AssumeTaskRole assumes roleArn as an ecs-local session, the same way ECS
+// itself would scope a container's credentials to TaskRoleArn.
+func AssumeTaskRole(sess *session.Session, roleArn string) (*sts.Credentials, error) {
+	stsClient := sts.New(sess)
+	role, err := stsClient.AssumeRole(&sts.AssumeRoleInput{
+		DurationSeconds: aws.Int64(3600),
+		RoleArn:         aws.String(roleArn),
+		RoleSessionName: aws.String("ecs-local"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return role.Credentials, nil
+}
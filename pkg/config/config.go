@@ -0,0 +1,140 @@
+// Package config loads ecs-local's configuration from flags, environment
+// variables and a config file into a single typed Config, with flags taking
+// precedence over environment, which takes precedence over the file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is prepended to every environment variable ecs-local reads, e.g.
+// ECS_LOCAL_PROFILE for the "profile" setting.
+const EnvPrefix = "ECS_LOCAL"
+
+// DefaultConfigFile is used when no config file is found via RelocateConfig.
+const DefaultConfigFile = "ecs-local-config.yaml"
+
+// Config is the fully resolved set of settings ecs-local runs with.
+type Config struct {
+	ConfigFile string   `mapstructure:"config"`
+	Profile    string   `mapstructure:"profile"`
+	Region     string   `mapstructure:"region"`
+	TaskDef    string   `mapstructure:"taskdef"`
+	Action     string   `mapstructure:"action"`
+	Container  string   `mapstructure:"container"`
+	Mounts     []string `mapstructure:"mounts"`
+	Envs       []string `mapstructure:"envs"`
+	DockerHost string   `mapstructure:"docker-host"`
+	Verbose    bool     `mapstructure:"verbose"`
+	Write      bool     `mapstructure:"write"`
+
+	// RedactSecrets keeps values resolved from ECS Secrets/EnvironmentFiles
+	// out of --verbose debug logging.
+	RedactSecrets bool `mapstructure:"redact-secrets"`
+}
+
+// SetDefaults registers the baseline values used when a setting is not
+// supplied by flag, environment variable or config file.
+func SetDefaults() {
+	viper.SetDefault("config", DefaultConfigFile)
+	viper.SetDefault("profile", "default")
+	viper.SetDefault("region", "us-east-1")
+	viper.SetDefault("action", "bundle exec rails c")
+}
+
+// Load resolves the config file (if any), layers in ECS_LOCAL_* environment
+// variables, and unmarshals the result into a Config. Flags must already be
+// bound to viper (see cmd/ecs-local) before Load is called.
+func Load(explicitConfigFile string) (*Config, error) {
+	path, found := RelocateConfig(explicitConfigFile)
+	if found {
+		viper.SetConfigFile(path)
+		if err := viper.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("reading config file %s: %w", path, err)
+		}
+	} else if explicitConfigFile != "" {
+		return nil, fmt.Errorf("config file %q not found", explicitConfigFile)
+	}
+
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.AutomaticEnv()
+
+	var cfg Config
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// RelocateConfig finds the config file to read. An explicit path is used
+// as-is; otherwise it searches, in order, ./ecs-local-config.yaml,
+// $XDG_CONFIG_HOME/ecs-local/config.yaml (or ~/.config/ecs-local/config.yaml
+// if XDG_CONFIG_HOME is unset) and /etc/ecs-local/config.yaml.
+func RelocateConfig(explicitConfigFile string) (string, bool) {
+	if explicitConfigFile != "" {
+		abs, err := filepath.Abs(explicitConfigFile)
+		if err != nil {
+			return "", false
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return "", false
+		}
+		return abs, true
+	}
+
+	var candidates []string
+	candidates = append(candidates, DefaultConfigFile)
+
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		candidates = append(candidates, filepath.Join(xdg, "ecs-local", "config.yaml"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".config", "ecs-local", "config.yaml"))
+	}
+
+	candidates = append(candidates, "/etc/ecs-local/config.yaml")
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+	}
+
+	return "", false
+}
+
+// Validate fails fast on a Config that can't be used to run anything, before
+// any AWS call is made.
+func Validate(cfg *Config) error {
+	if cfg.TaskDef == "" {
+		return errors.New("no taskdef defined")
+	}
+	return nil
+}
+
+// Write persists the current viper settings (i.e. whatever flags were
+// passed) to path, creating it first if necessary. An empty path writes to
+// DefaultConfigFile in the current directory.
+func Write(path string) error {
+	if path == "" {
+		path = DefaultConfigFile
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.OpenFile(abs, os.O_RDONLY|os.O_CREATE, 0666); err != nil {
+		return err
+	}
+
+	viper.SetConfigFile(abs)
+	viper.SetConfigType("yaml")
+	return viper.WriteConfig()
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func resetViper() {
+	viper.Reset()
+	SetDefaults()
+}
+
+func writeConfigFile(t *testing.T, dir, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, "ecs-local-config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFileOverridesDefault(t *testing.T) {
+	resetViper()
+	defer resetViper()
+
+	path := writeConfigFile(t, t.TempDir(), "region: us-west-2\n")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if cfg.Region != "us-west-2" {
+		t.Errorf("Region = %q, want us-west-2 (file should beat the default)", cfg.Region)
+	}
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	resetViper()
+	defer resetViper()
+
+	path := writeConfigFile(t, t.TempDir(), "profile: file-profile\n")
+
+	os.Setenv("ECS_LOCAL_PROFILE", "env-profile")
+	defer os.Unsetenv("ECS_LOCAL_PROFILE")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if cfg.Profile != "env-profile" {
+		t.Errorf("Profile = %q, want env-profile (env should beat the file)", cfg.Profile)
+	}
+}
+
+func TestLoadFlagOverridesEnv(t *testing.T) {
+	resetViper()
+	defer resetViper()
+
+	path := writeConfigFile(t, t.TempDir(), "profile: file-profile\n")
+
+	os.Setenv("ECS_LOCAL_PROFILE", "env-profile")
+	defer os.Unsetenv("ECS_LOCAL_PROFILE")
+
+	// A bound flag is indistinguishable from viper.Set's perspective.
+	viper.Set("profile", "flag-profile")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if cfg.Profile != "flag-profile" {
+		t.Errorf("Profile = %q, want flag-profile (flag should beat env)", cfg.Profile)
+	}
+}
+
+func TestLoadMissingExplicitConfigFile(t *testing.T) {
+	resetViper()
+	defer resetViper()
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected an error for a missing explicit config file")
+	}
+}
+
+func TestRelocateConfigExplicitPath(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "region: us-east-1\n")
+
+	got, found := RelocateConfig(path)
+	if !found {
+		t.Fatal("expected the explicit config file to be found")
+	}
+	abs, _ := filepath.Abs(path)
+	if got != abs {
+		t.Errorf("got %q, want %q", got, abs)
+	}
+}
+
+func TestRelocateConfigExplicitPathMissing(t *testing.T) {
+	if _, found := RelocateConfig(filepath.Join(t.TempDir(), "missing.yaml")); found {
+		t.Fatal("expected a missing explicit config file to report not found")
+	}
+}
+
+func TestRelocateConfigSearchOrder(t *testing.T) {
+	cwd := t.TempDir()
+	xdg := t.TempDir()
+
+	origCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(cwd); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(origCwd)
+
+	origXDG, hadXDG := os.LookupEnv("XDG_CONFIG_HOME")
+	os.Setenv("XDG_CONFIG_HOME", xdg)
+	defer func() {
+		if hadXDG {
+			os.Setenv("XDG_CONFIG_HOME", origXDG)
+		} else {
+			os.Unsetenv("XDG_CONFIG_HOME")
+		}
+	}()
+
+	xdgDir := filepath.Join(xdg, "ecs-local")
+	if err := os.MkdirAll(xdgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	xdgPath := filepath.Join(xdgDir, "config.yaml")
+	if err := os.WriteFile(xdgPath, []byte("region: us-west-1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// With no ./ecs-local-config.yaml in cwd, the XDG config is next.
+	path, found := RelocateConfig("")
+	if !found {
+		t.Fatal("expected to find the XDG config file")
+	}
+	if path != xdgPath {
+		t.Errorf("got %q, want %q (XDG fallback)", path, xdgPath)
+	}
+
+	// A config file in cwd takes precedence over XDG. RelocateConfig returns
+	// it relative, since it's statted as DefaultConfigFile directly.
+	cwdPath := filepath.Join(cwd, DefaultConfigFile)
+	if err := os.WriteFile(cwdPath, []byte("region: us-east-1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path, found = RelocateConfig("")
+	if !found {
+		t.Fatal("expected to find the cwd config file")
+	}
+	if path != DefaultConfigFile {
+		t.Errorf("got %q, want %q (cwd takes precedence)", path, DefaultConfigFile)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(&Config{}); err == nil {
+		t.Fatal("expected an error for a Config with no TaskDef")
+	}
+	if err := Validate(&Config{TaskDef: "stage-accounts"}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,41 @@
+// Package ecrauth turns an ECR authorization token into Docker registry
+// credentials.
+package ecrauth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// Login fetches an ECR authorization token for the session's account and
+// decodes it into Docker's AuthConfiguration, ready to pass to PullImage.
+func Login(sess *session.Session) (docker.AuthConfiguration, error) {
+	ecrClient := ecr.New(sess)
+	result, err := ecrClient.GetAuthorizationToken(&ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return docker.AuthConfiguration{}, err
+	}
+
+	authData := result.AuthorizationData[0]
+
+	data, err := base64.StdEncoding.DecodeString(*authData.AuthorizationToken)
+	if err != nil {
+		return docker.AuthConfiguration{}, err
+	}
+
+	userpass := strings.SplitN(string(data), ":", 2)
+	if len(userpass) != 2 {
+		return docker.AuthConfiguration{}, fmt.Errorf("unexpected ECR authorization token format")
+	}
+
+	return docker.AuthConfiguration{
+		Username:      userpass[0],
+		Password:      userpass[1],
+		ServerAddress: *authData.ProxyEndpoint,
+	}, nil
+}
@@ -0,0 +1,848 @@
+// Package runner drives a task definition's containers against a local
+// Docker daemon: it pulls every image, stands up a compose-style network,
+// brings sidecars up in DependsOn order, and execs into the primary
+// container.
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/docker/docker/pkg/term"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultDependsOnCondition is what ECS assumes when a container lists
+// another in DependsOn without an explicit Condition.
+const defaultDependsOnCondition = "START"
+
+// dependencyWaitTimeout bounds how long we wait for a sidecar's DependsOn
+// condition (HEALTHY/COMPLETE/SUCCESS) to be satisfied before giving up.
+const dependencyWaitTimeout = 2 * time.Minute
+
+// ExitError reports the primary container's own exit code, so callers can
+// exit with the same code the container did rather than a generic failure
+// code. Mirrors the role os/exec.ExitError played back when the primary
+// container ran under `docker run`.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("container exited with code %d", e.Code)
+}
+
+// Options configures how Run execs into the primary container.
+type Options struct {
+	// Container selects the primary container to exec into by name. Empty
+	// selects the essential container, or the first container.
+	Container string
+	// Action is the command run inside the primary container, overriding its
+	// own Command.
+	Action string
+	// Mounts are host:container bind mounts applied to the primary container.
+	Mounts []string
+	// Envs are NAME=value pairs applied to the primary container, in
+	// addition to its own Environment.
+	Envs []string
+	// TaskRoleCredentials, if set, are injected into the primary container as
+	// AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY/AWS_SESSION_TOKEN.
+	TaskRoleCredentials *sts.Credentials
+	// NoPull skips pulling images, assuming they're already present locally.
+	NoPull bool
+	// Detach starts the primary container in the background, under a
+	// deterministic name, instead of exec'ing into it. Pair with the `exec`
+	// subcommand to attach to it afterwards.
+	Detach bool
+}
+
+// runningContainer tracks a sidecar we started, so it can be waited on by
+// name (for DependsOn) and torn down on exit.
+type runningContainer struct {
+	name string
+	id   string
+}
+
+// Run pulls every container image in task, starts its sidecars on a shared
+// network in dependency order, and execs into the primary container
+// selected by opts.Container.
+func Run(client *docker.Client, task *ecs.TaskDefinition, auth docker.AuthConfiguration, opts Options) error {
+	if len(task.ContainerDefinitions) == 0 {
+		return fmt.Errorf("task definition has no container definitions")
+	}
+
+	primary, err := SelectPrimaryContainer(task.ContainerDefinitions, opts.Container)
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("Using %q as the primary container", *primary.Name)
+
+	sidecarOrder, err := orderSidecars(task.ContainerDefinitions, primary)
+	if err != nil {
+		return err
+	}
+
+	if !opts.NoPull {
+		if err := Pull(client, task, auth); err != nil {
+			return err
+		}
+	}
+
+	// Create a per-run, user-defined network so containers can reach each
+	// other by container name, the same way `docker-compose` wires a project
+	// network.
+	networkName := fmt.Sprintf("ecs-local-%s-%d", sanitizeNetworkName(*task.TaskDefinitionArn), os.Getpid())
+	network, err := client.CreateNetwork(docker.CreateNetworkOptions{
+		Name:   networkName,
+		Driver: "bridge",
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("Created network %s (%s)", network.Name, network.ID)
+
+	var startedContainers []*runningContainer
+	runningByName := map[string]*runningContainer{}
+
+	teardown := func() {
+		for i := len(startedContainers) - 1; i >= 0; i-- {
+			rc := startedContainers[i]
+			logrus.Debugf("Stopping %s", rc.name)
+			if err := client.StopContainer(rc.id, 5); err != nil {
+				logrus.Debugf("Error stopping %s: %s", rc.name, err.Error())
+			}
+			if err := client.RemoveContainer(docker.RemoveContainerOptions{ID: rc.id, Force: true}); err != nil {
+				logrus.Debugf("Error removing %s: %s", rc.name, err.Error())
+			}
+		}
+		logrus.Debugf("Removing network %s", network.Name)
+		if err := client.RemoveNetwork(network.ID); err != nil {
+			logrus.Debugf("Error removing network %s: %s", network.Name, err.Error())
+		}
+	}
+
+	// Tear the sidecars and network down on Ctrl-C too, not just on the
+	// normal return path below. A detached run that actually succeeds is
+	// meant to outlive this process - a later `ecs-local exec` attaches to
+	// what's left running - so it skips the signal handler and the
+	// unconditional teardown a foreground run gets. It still tears down on
+	// an error return, though: a sidecar or dependency failure partway
+	// through shouldn't leak the network and whatever containers did start.
+	detachedSuccess := false
+	if !opts.Detach {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigCh
+			teardown()
+			os.Exit(0)
+		}()
+		defer teardown()
+	} else {
+		defer func() {
+			if !detachedSuccess {
+				teardown()
+			}
+		}()
+	}
+
+	for _, sidecar := range sidecarOrder {
+		if err := waitForDependencies(client, sidecar, runningByName); err != nil {
+			return err
+		}
+
+		fmt.Printf("Starting sidecar %s \n", *sidecar.Name)
+		rc, err := startSidecar(client, sidecar, task, networkName, runningByName)
+		if err != nil {
+			return err
+		}
+		startedContainers = append(startedContainers, rc)
+		runningByName[rc.name] = rc
+	}
+
+	if err := waitForDependencies(client, primary, runningByName); err != nil {
+		return err
+	}
+
+	rc, runErr := execPrimary(client, primary, task, networkName, opts, runningByName)
+	if rc != nil {
+		// Registering after the fact is safe: teardown and the signal
+		// handler above close over startedContainers itself, not a
+		// snapshot of it, so they see this append even if it races a
+		// concurrent Ctrl-C.
+		startedContainers = append(startedContainers, rc)
+	}
+	detachedSuccess = opts.Detach && runErr == nil
+	return runErr
+}
+
+// Pull fetches every image referenced by task's container definitions,
+// without starting anything.
+func Pull(client *docker.Client, task *ecs.TaskDefinition, auth docker.AuthConfiguration) error {
+	for _, image := range imagesToPull(task.ContainerDefinitions) {
+		fmt.Printf("Pulling %s \n", image)
+		if err := client.PullImage(docker.PullImageOptions{
+			Repository:   image,
+			OutputStream: os.Stdout,
+		}, auth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execPrimary creates and starts the primary container, under a deterministic
+// name so a later `ecs-local exec` can find it. A detached run returns as
+// soon as the container has started; otherwise it attaches the caller's
+// stdin/stdout/stderr and blocks until the container exits, returning an
+// *ExitError for a non-zero exit code.
+//
+// It always returns the created container (even alongside an error) so Run
+// can register it for teardown.
+func execPrimary(client *docker.Client, primary *ecs.ContainerDefinition, task *ecs.TaskDefinition, networkName string, opts Options, running map[string]*runningContainer) (*runningContainer, error) {
+	name := ContainerName(*task.TaskDefinitionArn, *primary.Name)
+
+	command := strings.Split(opts.Action, " ")
+	if opts.Action == "" {
+		command = nil
+		for _, v := range primary.Command {
+			command = append(command, *v)
+		}
+	}
+	logrus.Debugf("Running command \"%s\"", strings.Join(command, " "))
+
+	tty := !opts.Detach && term.IsTerminal(os.Stdin.Fd())
+
+	config, hostConfig, err := containerConfig(primary, networkName, primaryEnv(primary, task, opts), command, running)
+	if err != nil {
+		return nil, err
+	}
+	config.Tty = tty
+	config.OpenStdin = !opts.Detach
+	config.StdinOnce = !opts.Detach
+	config.AttachStdin = !opts.Detach
+	config.AttachStdout = !opts.Detach
+	config.AttachStderr = !opts.Detach
+	hostConfig.Binds = bindMounts(opts.Mounts)
+
+	c, err := client.CreateContainer(docker.CreateContainerOptions{
+		Name:             name,
+		Config:           config,
+		HostConfig:       hostConfig,
+		NetworkingConfig: containerNetworkingConfig(networkName, *primary.Name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating container %s: %s", name, err.Error())
+	}
+	rc := &runningContainer{name: name, id: c.ID}
+
+	if opts.Detach {
+		if err := client.StartContainer(c.ID, nil); err != nil {
+			return rc, fmt.Errorf("starting container %s: %s", name, err.Error())
+		}
+		fmt.Printf("Started %s in the background \n", name)
+		return rc, nil
+	}
+
+	exitCode, err := attachAndRun(client, c.ID, tty)
+	if err != nil {
+		return rc, err
+	}
+	if exitCode != 0 {
+		return rc, &ExitError{Code: exitCode}
+	}
+	return rc, nil
+}
+
+// attachAndRun attaches to containerID's stdio before starting it, so no
+// output is lost between start and attach, then blocks until it exits,
+// returning its exit code. A tty container is run with the local terminal in
+// raw mode so control characters (Ctrl-C, Ctrl-D) pass through to it instead
+// of being interpreted locally, matching `docker run -it`.
+func attachAndRun(client *docker.Client, containerID string, tty bool) (int, error) {
+	if tty {
+		if state, err := term.SetRawTerminal(os.Stdin.Fd()); err == nil {
+			defer term.RestoreTerminal(os.Stdin.Fd(), state)
+		}
+	}
+
+	success := make(chan struct{})
+	waiter, err := client.AttachToContainerNonBlocking(docker.AttachToContainerOptions{
+		Container:    containerID,
+		InputStream:  os.Stdin,
+		OutputStream: os.Stdout,
+		ErrorStream:  os.Stderr,
+		Stream:       true,
+		Stdin:        true,
+		Stdout:       true,
+		Stderr:       true,
+		RawTerminal:  tty,
+		Success:      success,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("attaching to container: %s", err.Error())
+	}
+	defer waiter.Close()
+	<-success
+	success <- struct{}{}
+
+	if err := client.StartContainer(containerID, nil); err != nil {
+		return 0, fmt.Errorf("starting container: %s", err.Error())
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		logrus.Debugf("Stopping %s", containerID)
+		if err := client.StopContainer(containerID, 5); err != nil {
+			logrus.Debugf("Error stopping %s: %s", containerID, err.Error())
+		}
+	}()
+
+	exitCode, err := client.WaitContainer(containerID)
+	if err != nil {
+		return 0, err
+	}
+	if err := waiter.Wait(); err != nil {
+		logrus.Debugf("Error waiting on attach stream: %s", err.Error())
+	}
+	return exitCode, nil
+}
+
+// primaryEnv assembles the primary container's environment: its own
+// Environment, an assumed task role's credentials, and finally opts.Envs,
+// which take precedence since they're the most specific to this invocation.
+func primaryEnv(primary *ecs.ContainerDefinition, task *ecs.TaskDefinition, opts Options) []string {
+	var env []string
+	for _, e := range primary.Environment {
+		env = append(env, fmt.Sprintf("%s=%s", *e.Name, *e.Value))
+	}
+
+	if creds := opts.TaskRoleCredentials; creds != nil {
+		logrus.Debugf("Using assumed container role %s", *task.TaskRoleArn)
+		env = append(env,
+			fmt.Sprintf("AWS_ACCESS_KEY_ID=%s", *creds.AccessKeyId),
+			fmt.Sprintf("AWS_SECRET_ACCESS_KEY=%s", *creds.SecretAccessKey),
+			fmt.Sprintf("AWS_SESSION_TOKEN=%s", *creds.SessionToken),
+		)
+	}
+
+	env = append(env, opts.Envs...)
+	return env
+}
+
+// bindMounts turns "src:dest" mount flags into Docker's "src:dest" bind
+// mount syntax, which happens to be identical.
+func bindMounts(mounts []string) []string {
+	var binds []string
+	for _, mount := range mounts {
+		parts := strings.SplitN(mount, ":", 2)
+		binds = append(binds, fmt.Sprintf("%s:%s", parts[0], parts[1]))
+	}
+	return binds
+}
+
+// containerConfig builds the Docker Config/HostConfig shared by every
+// container started from a task definition - primary or sidecar: image,
+// command, working directory, user, entrypoint, health check, resource
+// limits, log configuration, links, volumesFrom and port mappings. Callers
+// layer on whatever is specific to their container (the primary's stdio
+// attachment and bind mounts; a sidecar needs nothing more).
+//
+// running resolves cd's Links/VolumesFrom (ECS container names) to the
+// sidecars already started under this run, since Docker looks those up by
+// actual container name/ID, not by the network alias a container is
+// otherwise reachable under.
+func containerConfig(cd *ecs.ContainerDefinition, networkName string, env, command []string, running map[string]*runningContainer) (*docker.Config, *docker.HostConfig, error) {
+	config := &docker.Config{
+		Image:        *cd.Image,
+		Env:          env,
+		Cmd:          command,
+		ExposedPorts: map[docker.Port]struct{}{},
+		Healthcheck:  healthConfig(cd.HealthCheck),
+	}
+	if cd.WorkingDirectory != nil {
+		config.WorkingDir = *cd.WorkingDirectory
+	}
+	if cd.User != nil {
+		config.User = *cd.User
+	}
+	for _, e := range cd.EntryPoint {
+		config.Entrypoint = append(config.Entrypoint, *e)
+	}
+
+	hostConfig := &docker.HostConfig{NetworkMode: networkName}
+	links, err := resolveLinks(cd.Links, running)
+	if err != nil {
+		return nil, nil, err
+	}
+	hostConfig.Links = links
+	volumesFrom, err := resolveVolumesFrom(cd.VolumesFrom, running)
+	if err != nil {
+		return nil, nil, err
+	}
+	hostConfig.VolumesFrom = volumesFrom
+	for _, p := range cd.PortMappings {
+		containerPort := dockerPort(p)
+		config.ExposedPorts[containerPort] = struct{}{}
+		if p.HostPort != nil && *p.HostPort != 0 {
+			if hostConfig.PortBindings == nil {
+				hostConfig.PortBindings = map[docker.Port][]docker.PortBinding{}
+			}
+			hostConfig.PortBindings[containerPort] = []docker.PortBinding{{HostPort: fmt.Sprintf("%d", *p.HostPort)}}
+		}
+	}
+	applyResourceLimits(hostConfig, cd)
+	applyLinuxParameters(hostConfig, cd.LinuxParameters)
+	applyLogConfiguration(hostConfig, cd.LogConfiguration)
+
+	return config, hostConfig, nil
+}
+
+// resolveLinks translates links ("container" or "container:alias", using the
+// ECS container name) into Docker's own "id:alias" syntax, looking each
+// container up in running since Docker resolves links against its container
+// store by name/ID, not by the network alias a container is reachable under.
+// The alias defaults to the ECS container name itself, matching what a bare
+// "container" link implies.
+func resolveLinks(links []*string, running map[string]*runningContainer) ([]string, error) {
+	var resolved []string
+	for _, l := range links {
+		name, alias := *l, *l
+		if i := strings.Index(*l, ":"); i != -1 {
+			name, alias = (*l)[:i], (*l)[i+1:]
+		}
+		rc, ok := running[name]
+		if !ok {
+			return nil, fmt.Errorf("linked container %q isn't running yet (check its dependsOn ordering)", name)
+		}
+		resolved = append(resolved, fmt.Sprintf("%s:%s", rc.id, alias))
+	}
+	return resolved, nil
+}
+
+// resolveVolumesFrom translates each VolumeFrom's ECS container name into
+// Docker's own "id[:ro]" syntax, looking it up in running for the same
+// reason resolveLinks does.
+func resolveVolumesFrom(volumesFrom []*ecs.VolumeFrom, running map[string]*runningContainer) ([]string, error) {
+	var resolved []string
+	for _, v := range volumesFrom {
+		rc, ok := running[*v.SourceContainer]
+		if !ok {
+			return nil, fmt.Errorf("volumesFrom container %q isn't running yet (check its dependsOn ordering)", *v.SourceContainer)
+		}
+		if v.ReadOnly != nil && *v.ReadOnly {
+			resolved = append(resolved, fmt.Sprintf("%s:ro", rc.id))
+		} else {
+			resolved = append(resolved, rc.id)
+		}
+	}
+	return resolved, nil
+}
+
+// containerNetworkingConfig joins networkName under alias, so other
+// containers on the network can reach it by its ECS container name
+// regardless of what its actual, namespaced Docker container name is.
+func containerNetworkingConfig(networkName, alias string) *docker.NetworkingConfig {
+	return &docker.NetworkingConfig{
+		EndpointsConfig: map[string]*docker.EndpointConfig{
+			networkName: {Aliases: []string{alias}},
+		},
+	}
+}
+
+// applyResourceLimits translates cd's Cpu/Memory/MemoryReservation/Ulimits
+// into their HostConfig equivalents.
+func applyResourceLimits(hostConfig *docker.HostConfig, cd *ecs.ContainerDefinition) {
+	if cd.Cpu != nil {
+		hostConfig.CPUShares = *cd.Cpu
+	}
+	if cd.Memory != nil {
+		hostConfig.Memory = *cd.Memory * 1024 * 1024
+	}
+	if cd.MemoryReservation != nil {
+		hostConfig.MemoryReservation = *cd.MemoryReservation * 1024 * 1024
+	}
+	for _, u := range cd.Ulimits {
+		hostConfig.Ulimits = append(hostConfig.Ulimits, docker.ULimit{
+			Name: *u.Name,
+			Soft: *u.SoftLimit,
+			Hard: *u.HardLimit,
+		})
+	}
+}
+
+// applyLinuxParameters translates the subset of LinuxParameters Docker's API
+// also exposes: added/dropped capabilities and /dev/shm size. Devices,
+// tmpfs mounts and swap tuning aren't supported locally and are ignored.
+func applyLinuxParameters(hostConfig *docker.HostConfig, lp *ecs.LinuxParameters) {
+	if lp == nil {
+		return
+	}
+	if lp.Capabilities != nil {
+		for _, c := range lp.Capabilities.Add {
+			hostConfig.CapAdd = append(hostConfig.CapAdd, *c)
+		}
+		for _, c := range lp.Capabilities.Drop {
+			hostConfig.CapDrop = append(hostConfig.CapDrop, *c)
+		}
+	}
+	if lp.SharedMemorySize != nil {
+		hostConfig.ShmSize = *lp.SharedMemorySize * 1024 * 1024
+	}
+}
+
+// applyLogConfiguration translates cd's LogConfiguration into Docker's
+// LogConfig, dropping SecretOptions since they require resolving a Secrets
+// Manager/SSM value ecs-local doesn't have a way to inject into a log driver.
+func applyLogConfiguration(hostConfig *docker.HostConfig, lc *ecs.LogConfiguration) {
+	if lc == nil {
+		return
+	}
+	logConfig := docker.LogConfig{Type: *lc.LogDriver}
+	if len(lc.Options) > 0 {
+		logConfig.Config = map[string]string{}
+		for k, v := range lc.Options {
+			logConfig.Config[k] = *v
+		}
+	}
+	hostConfig.LogConfig = logConfig
+}
+
+// healthConfig translates an ECS HealthCheck into Docker's HealthConfig. A
+// nil HealthCheck returns nil, leaving the container's own image HEALTHCHECK
+// (if any) in effect.
+func healthConfig(hc *ecs.HealthCheck) *docker.HealthConfig {
+	if hc == nil {
+		return nil
+	}
+	health := &docker.HealthConfig{}
+	for _, c := range hc.Command {
+		health.Test = append(health.Test, *c)
+	}
+	if hc.Interval != nil {
+		health.Interval = time.Duration(*hc.Interval) * time.Second
+	}
+	if hc.Timeout != nil {
+		health.Timeout = time.Duration(*hc.Timeout) * time.Second
+	}
+	if hc.StartPeriod != nil {
+		health.StartPeriod = time.Duration(*hc.StartPeriod) * time.Second
+	}
+	if hc.Retries != nil {
+		health.Retries = int(*hc.Retries)
+	}
+	return health
+}
+
+// imagesToPull returns the distinct set of images referenced across all
+// container definitions, in task definition order.
+func imagesToPull(containers []*ecs.ContainerDefinition) []string {
+	seen := map[string]bool{}
+	var images []string
+	for _, c := range containers {
+		if c.Image == nil || seen[*c.Image] {
+			continue
+		}
+		seen[*c.Image] = true
+		images = append(images, *c.Image)
+	}
+	return images
+}
+
+// SelectPrimaryContainer picks the container to finally exec into: the one
+// named by want, falling back to the essential container, falling back
+// to the first container in the task definition.
+func SelectPrimaryContainer(containers []*ecs.ContainerDefinition, want string) (*ecs.ContainerDefinition, error) {
+	if want != "" {
+		if c := containerByName(containers, want); c != nil {
+			return c, nil
+		}
+		return nil, fmt.Errorf("no container named %q in task definition", want)
+	}
+
+	for _, c := range containers {
+		if c.Essential != nil && *c.Essential {
+			return c, nil
+		}
+	}
+
+	return containers[0], nil
+}
+
+func containerByName(containers []*ecs.ContainerDefinition, name string) *ecs.ContainerDefinition {
+	for _, c := range containers {
+		if c.Name != nil && *c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// orderSidecars topologically sorts every container other than primary by
+// DependsOn, so that a sidecar always appears after the sidecars it depends
+// on. DependsOn entries naming the primary container are ignored, since the
+// primary is always started last.
+func orderSidecars(containers []*ecs.ContainerDefinition, primary *ecs.ContainerDefinition) ([]*ecs.ContainerDefinition, error) {
+	var sidecars []*ecs.ContainerDefinition
+	for _, c := range containers {
+		if c != primary {
+			sidecars = append(sidecars, c)
+		}
+	}
+
+	var ordered []*ecs.ContainerDefinition
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+
+	var visit func(c *ecs.ContainerDefinition) error
+	visit = func(c *ecs.ContainerDefinition) error {
+		name := *c.Name
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular dependsOn involving container %q", name)
+		}
+		state[name] = visiting
+
+		for _, dep := range c.DependsOn {
+			if dep.ContainerName == nil || *dep.ContainerName == *primary.Name {
+				continue
+			}
+			depContainer := containerByName(sidecars, *dep.ContainerName)
+			if depContainer == nil {
+				continue
+			}
+			if err := visit(depContainer); err != nil {
+				return err
+			}
+		}
+
+		state[name] = visited
+		ordered = append(ordered, c)
+		return nil
+	}
+
+	for _, c := range sidecars {
+		if err := visit(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// waitForDependencies blocks until every container that cd.DependsOn names
+// has reached the required condition, skipping any dependency we aren't
+// running ourselves (e.g. the primary container, which is started last).
+func waitForDependencies(client *docker.Client, cd *ecs.ContainerDefinition, running map[string]*runningContainer) error {
+	for _, dep := range cd.DependsOn {
+		if dep.ContainerName == nil {
+			continue
+		}
+		rc, ok := running[*dep.ContainerName]
+		if !ok {
+			continue
+		}
+
+		condition := defaultDependsOnCondition
+		if dep.Condition != nil {
+			condition = *dep.Condition
+		}
+
+		logrus.Debugf("Waiting for %s to be %s before starting %s", rc.name, condition, *cd.Name)
+		if err := waitForCondition(client, rc, condition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForCondition polls the container's state until it satisfies condition,
+// mirroring the START / HEALTHY / COMPLETE / SUCCESS semantics ECS uses for
+// container DependsOn.
+func waitForCondition(client *docker.Client, rc *runningContainer, condition string) error {
+	switch condition {
+	case "START":
+		return nil
+	case "HEALTHY":
+		return pollContainer(client, rc, func(c *docker.Container) (bool, error) {
+			if c.State.Health.Status == "unhealthy" {
+				return false, fmt.Errorf("container %s is unhealthy", rc.name)
+			}
+			return c.State.Health.Status == "healthy", nil
+		})
+	case "COMPLETE", "SUCCESS":
+		return pollContainer(client, rc, func(c *docker.Container) (bool, error) {
+			if c.State.Running {
+				return false, nil
+			}
+			if condition == "SUCCESS" && c.State.ExitCode != 0 {
+				return false, fmt.Errorf("container %s exited with code %d", rc.name, c.State.ExitCode)
+			}
+			return true, nil
+		})
+	default:
+		return fmt.Errorf("unsupported dependsOn condition %q on container %s", condition, rc.name)
+	}
+}
+
+func pollContainer(client *docker.Client, rc *runningContainer, done func(*docker.Container) (bool, error)) error {
+	deadline := time.After(dependencyWaitTimeout)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-deadline:
+			return fmt.Errorf("timed out waiting on container %s", rc.name)
+		case <-ticker.C:
+			c, err := client.InspectContainer(rc.id)
+			if err != nil {
+				return err
+			}
+			ok, err := done(c)
+			if err != nil {
+				return err
+			}
+			if ok {
+				return nil
+			}
+		}
+	}
+}
+
+// startSidecar creates and starts cd detached, joined to networkName under a
+// name namespaced to task the same way the primary container is (so two task
+// definitions that happen to share a sidecar name, or a re-run after a
+// skipped teardown, don't collide), aliased on the network to its own ECS
+// container name so other containers can still reach it by name.
+func startSidecar(client *docker.Client, cd *ecs.ContainerDefinition, task *ecs.TaskDefinition, networkName string, running map[string]*runningContainer) (*runningContainer, error) {
+	name := ContainerName(*task.TaskDefinitionArn, *cd.Name)
+
+	var env []string
+	for _, e := range cd.Environment {
+		env = append(env, fmt.Sprintf("%s=%s", *e.Name, *e.Value))
+	}
+
+	var command []string
+	for _, v := range cd.Command {
+		command = append(command, *v)
+	}
+
+	config, hostConfig, err := containerConfig(cd, networkName, env, command, running)
+	if err != nil {
+		return nil, fmt.Errorf("starting sidecar %s: %s", *cd.Name, err.Error())
+	}
+
+	c, err := client.CreateContainer(docker.CreateContainerOptions{
+		Name:             name,
+		Config:           config,
+		HostConfig:       hostConfig,
+		NetworkingConfig: containerNetworkingConfig(networkName, *cd.Name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating sidecar %s: %s", *cd.Name, err.Error())
+	}
+
+	if err := client.StartContainer(c.ID, nil); err != nil {
+		return nil, fmt.Errorf("starting sidecar %s: %s", *cd.Name, err.Error())
+	}
+
+	return &runningContainer{name: *cd.Name, id: c.ID}, nil
+}
+
+func dockerPort(p *ecs.PortMapping) docker.Port {
+	proto := "tcp"
+	if p.Protocol != nil {
+		proto = *p.Protocol
+	}
+	return docker.Port(fmt.Sprintf("%d/%s", *p.ContainerPort, proto))
+}
+
+// sanitizeNetworkName makes a task definition ARN safe to use in a Docker
+// network name.
+func sanitizeNetworkName(taskDefinitionArn string) string {
+	name := taskDefinitionArn
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		name = name[i+1:]
+	}
+	return strings.NewReplacer(":", "-", "/", "-").Replace(name)
+}
+
+// NewDockerClient opens a client for the Docker daemon at host, e.g.
+// tcp://localhost:2375 or npipe:////./pipe/docker_engine. An empty host
+// resolves the endpoint the same way the docker CLI does, from the
+// DOCKER_HOST/DOCKER_TLS_VERIFY/DOCKER_CERT_PATH environment variables,
+// falling back to the OS-appropriate local socket.
+func NewDockerClient(host string) (*docker.Client, error) {
+	if host == "" {
+		return docker.NewClientFromEnv()
+	}
+	return docker.NewClient(host)
+}
+
+// ContainerName is the deterministic, namespaced name a task definition's
+// container runs under - ecs-local-<sanitized task definition arn>-<name> -
+// so a later `ecs-local exec` can find the primary container again, and so
+// two task definitions sharing a container name (or a re-run after a skipped
+// teardown) don't collide.
+func ContainerName(taskDefinitionArn, containerName string) string {
+	return fmt.Sprintf("ecs-local-%s-%s", sanitizeNetworkName(taskDefinitionArn), containerName)
+}
+
+// ExecInto runs command inside name, an already-running container, through
+// Docker's exec API, attaching the caller's stdin/stdout/stderr. A terminal
+// stdin runs the exec in raw/tty mode, matching `docker exec -it`.
+func ExecInto(client *docker.Client, name string, command []string) error {
+	tty := term.IsTerminal(os.Stdin.Fd())
+
+	e, err := client.CreateExec(docker.CreateExecOptions{
+		Container:    name,
+		Cmd:          command,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          tty,
+	})
+	if err != nil {
+		return fmt.Errorf("creating exec in %s: %s", name, err.Error())
+	}
+
+	if tty {
+		if state, err := term.SetRawTerminal(os.Stdin.Fd()); err == nil {
+			defer term.RestoreTerminal(os.Stdin.Fd(), state)
+		}
+	}
+
+	success := make(chan struct{})
+	waiter, err := client.StartExecNonBlocking(e.ID, docker.StartExecOptions{
+		InputStream:  os.Stdin,
+		OutputStream: os.Stdout,
+		ErrorStream:  os.Stderr,
+		Tty:          tty,
+		RawTerminal:  tty,
+		Success:      success,
+	})
+	if err != nil {
+		return fmt.Errorf("starting exec in %s: %s", name, err.Error())
+	}
+	defer waiter.Close()
+	<-success
+	success <- struct{}{}
+
+	return waiter.Wait()
+}
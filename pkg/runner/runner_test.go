@@ -0,0 +1,368 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/sts"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func containerDef(name string, dependsOn ...string) *ecs.ContainerDefinition {
+	cd := &ecs.ContainerDefinition{Name: aws.String(name)}
+	for _, d := range dependsOn {
+		cd.DependsOn = append(cd.DependsOn, &ecs.ContainerDependency{ContainerName: aws.String(d)})
+	}
+	return cd
+}
+
+func names(containers []*ecs.ContainerDefinition) []string {
+	var n []string
+	for _, c := range containers {
+		n = append(n, *c.Name)
+	}
+	return n
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestOrderSidecarsRespectsDependsOn(t *testing.T) {
+	primary := containerDef("app", "envoy")
+	envoy := containerDef("envoy", "xray")
+	xray := containerDef("xray")
+
+	ordered, err := orderSidecars([]*ecs.ContainerDefinition{primary, envoy, xray}, primary)
+	if err != nil {
+		t.Fatalf("orderSidecars: %s", err)
+	}
+
+	n := names(ordered)
+	if len(n) != 2 {
+		t.Fatalf("expected 2 sidecars, got %v", n)
+	}
+	if indexOf(n, "xray") > indexOf(n, "envoy") {
+		t.Fatalf("expected xray before envoy, got %v", n)
+	}
+}
+
+func TestOrderSidecarsIgnoresDependsOnPrimary(t *testing.T) {
+	primary := containerDef("app")
+	sidecar := containerDef("redis", "app")
+
+	ordered, err := orderSidecars([]*ecs.ContainerDefinition{primary, sidecar}, primary)
+	if err != nil {
+		t.Fatalf("orderSidecars: %s", err)
+	}
+	if n := names(ordered); len(n) != 1 || n[0] != "redis" {
+		t.Fatalf("expected [redis], got %v", n)
+	}
+}
+
+func TestOrderSidecarsDetectsCircularDependsOn(t *testing.T) {
+	primary := containerDef("app")
+	a := containerDef("a", "b")
+	b := containerDef("b", "a")
+
+	if _, err := orderSidecars([]*ecs.ContainerDefinition{primary, a, b}, primary); err == nil {
+		t.Fatal("expected a circular dependsOn error, got nil")
+	}
+}
+
+func TestSanitizeNetworkName(t *testing.T) {
+	cases := map[string]string{
+		"arn:aws:ecs:us-east-1:123456789012:task-definition/stage-accounts:42": "stage-accounts-42",
+		"stage-accounts":      "stage-accounts",
+		"stage/accounts:prod": "accounts-prod",
+	}
+	for arn, want := range cases {
+		if got := sanitizeNetworkName(arn); got != want {
+			t.Errorf("sanitizeNetworkName(%q) = %q, want %q", arn, got, want)
+		}
+	}
+}
+
+func TestContainerName(t *testing.T) {
+	arn := "arn:aws:ecs:us-east-1:123456789012:task-definition/stage-accounts:42"
+	got := ContainerName(arn, "redis")
+	want := "ecs-local-stage-accounts-42-redis"
+	if got != want {
+		t.Errorf("ContainerName(%q, %q) = %q, want %q", arn, "redis", got, want)
+	}
+}
+
+func TestPrimaryEnv(t *testing.T) {
+	primary := &ecs.ContainerDefinition{
+		Environment: []*ecs.KeyValuePair{
+			{Name: aws.String("FOO"), Value: aws.String("bar")},
+		},
+	}
+	task := &ecs.TaskDefinition{TaskRoleArn: aws.String("arn:aws:iam::123456789012:role/task-role")}
+	opts := Options{
+		TaskRoleCredentials: &sts.Credentials{
+			AccessKeyId:     aws.String("AKID"),
+			SecretAccessKey: aws.String("SECRET"),
+			SessionToken:    aws.String("TOKEN"),
+		},
+		Envs: []string{"BAZ=qux"},
+	}
+
+	env := primaryEnv(primary, task, opts)
+	want := []string{
+		"FOO=bar",
+		"AWS_ACCESS_KEY_ID=AKID",
+		"AWS_SECRET_ACCESS_KEY=SECRET",
+		"AWS_SESSION_TOKEN=TOKEN",
+		"BAZ=qux",
+	}
+	if len(env) != len(want) {
+		t.Fatalf("got %v, want %v", env, want)
+	}
+	for i, w := range want {
+		if env[i] != w {
+			t.Errorf("env[%d] = %q, want %q", i, env[i], w)
+		}
+	}
+}
+
+func TestPrimaryEnvOptsOverrideOwnEnvironment(t *testing.T) {
+	primary := &ecs.ContainerDefinition{
+		Environment: []*ecs.KeyValuePair{
+			{Name: aws.String("FOO"), Value: aws.String("bar")},
+		},
+	}
+	opts := Options{Envs: []string{"FOO=overridden"}}
+
+	env := primaryEnv(primary, &ecs.TaskDefinition{}, opts)
+	if len(env) != 2 || env[1] != "FOO=overridden" {
+		t.Fatalf("got %v, want opts.Envs to follow and win on duplicate keys", env)
+	}
+}
+
+func TestBindMounts(t *testing.T) {
+	got := bindMounts([]string{"/host/path:/container/path", "/data:/data"})
+	want := []string{"/host/path:/container/path", "/data:/data"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("got[%d] = %q, want %q", i, got[i], w)
+		}
+	}
+}
+
+func TestDockerPort(t *testing.T) {
+	tcp := &ecs.PortMapping{ContainerPort: aws.Int64(8080)}
+	if got, want := dockerPort(tcp), docker.Port("8080/tcp"); got != want {
+		t.Errorf("dockerPort(tcp) = %q, want %q", got, want)
+	}
+
+	udp := &ecs.PortMapping{ContainerPort: aws.Int64(53), Protocol: aws.String("udp")}
+	if got, want := dockerPort(udp), docker.Port("53/udp"); got != want {
+		t.Errorf("dockerPort(udp) = %q, want %q", got, want)
+	}
+}
+
+func TestApplyResourceLimits(t *testing.T) {
+	hostConfig := &docker.HostConfig{}
+	cd := &ecs.ContainerDefinition{
+		Cpu:               aws.Int64(256),
+		Memory:            aws.Int64(128),
+		MemoryReservation: aws.Int64(64),
+		Ulimits: []*ecs.Ulimit{
+			{Name: aws.String("nofile"), SoftLimit: aws.Int64(1024), HardLimit: aws.Int64(2048)},
+		},
+	}
+
+	applyResourceLimits(hostConfig, cd)
+
+	if hostConfig.CPUShares != 256 {
+		t.Errorf("CPUShares = %d, want 256", hostConfig.CPUShares)
+	}
+	if hostConfig.Memory != 128*1024*1024 {
+		t.Errorf("Memory = %d, want %d", hostConfig.Memory, 128*1024*1024)
+	}
+	if hostConfig.MemoryReservation != 64*1024*1024 {
+		t.Errorf("MemoryReservation = %d, want %d", hostConfig.MemoryReservation, 64*1024*1024)
+	}
+	if len(hostConfig.Ulimits) != 1 || hostConfig.Ulimits[0].Name != "nofile" {
+		t.Errorf("Ulimits = %v", hostConfig.Ulimits)
+	}
+}
+
+func TestApplyLinuxParameters(t *testing.T) {
+	hostConfig := &docker.HostConfig{}
+	lp := &ecs.LinuxParameters{
+		Capabilities: &ecs.KernelCapabilities{
+			Add:  []*string{aws.String("SYS_PTRACE")},
+			Drop: []*string{aws.String("NET_RAW")},
+		},
+		SharedMemorySize: aws.Int64(64),
+	}
+
+	applyLinuxParameters(hostConfig, lp)
+
+	if len(hostConfig.CapAdd) != 1 || hostConfig.CapAdd[0] != "SYS_PTRACE" {
+		t.Errorf("CapAdd = %v", hostConfig.CapAdd)
+	}
+	if len(hostConfig.CapDrop) != 1 || hostConfig.CapDrop[0] != "NET_RAW" {
+		t.Errorf("CapDrop = %v", hostConfig.CapDrop)
+	}
+	if hostConfig.ShmSize != 64*1024*1024 {
+		t.Errorf("ShmSize = %d, want %d", hostConfig.ShmSize, 64*1024*1024)
+	}
+}
+
+func TestApplyLinuxParametersNil(t *testing.T) {
+	hostConfig := &docker.HostConfig{}
+	applyLinuxParameters(hostConfig, nil)
+	if hostConfig.CapAdd != nil || hostConfig.CapDrop != nil || hostConfig.ShmSize != 0 {
+		t.Errorf("expected a nil LinuxParameters to leave hostConfig untouched, got %+v", hostConfig)
+	}
+}
+
+func TestApplyLogConfiguration(t *testing.T) {
+	hostConfig := &docker.HostConfig{}
+	lc := &ecs.LogConfiguration{
+		LogDriver: aws.String("json-file"),
+		Options:   map[string]*string{"max-size": aws.String("10m")},
+	}
+
+	applyLogConfiguration(hostConfig, lc)
+
+	if hostConfig.LogConfig.Type != "json-file" {
+		t.Errorf("LogConfig.Type = %q, want json-file", hostConfig.LogConfig.Type)
+	}
+	if hostConfig.LogConfig.Config["max-size"] != "10m" {
+		t.Errorf("LogConfig.Config[max-size] = %q, want 10m", hostConfig.LogConfig.Config["max-size"])
+	}
+}
+
+func TestHealthConfig(t *testing.T) {
+	if got := healthConfig(nil); got != nil {
+		t.Errorf("healthConfig(nil) = %+v, want nil", got)
+	}
+
+	hc := &ecs.HealthCheck{
+		Command:     []*string{aws.String("CMD-SHELL"), aws.String("curl -f http://localhost/ || exit 1")},
+		Interval:    aws.Int64(30),
+		Timeout:     aws.Int64(5),
+		StartPeriod: aws.Int64(10),
+		Retries:     aws.Int64(3),
+	}
+	got := healthConfig(hc)
+	if len(got.Test) != 2 || got.Test[1] != "curl -f http://localhost/ || exit 1" {
+		t.Errorf("Test = %v", got.Test)
+	}
+	if got.Interval != 30*time.Second {
+		t.Errorf("Interval = %s, want 30s", got.Interval)
+	}
+	if got.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %s, want 5s", got.Timeout)
+	}
+	if got.StartPeriod != 10*time.Second {
+		t.Errorf("StartPeriod = %s, want 10s", got.StartPeriod)
+	}
+	if got.Retries != 3 {
+		t.Errorf("Retries = %d, want 3", got.Retries)
+	}
+}
+
+func TestResolveLinks(t *testing.T) {
+	running := map[string]*runningContainer{
+		"redis": {name: "redis", id: "abc123"},
+	}
+
+	got, err := resolveLinks([]*string{aws.String("redis")}, running)
+	if err != nil {
+		t.Fatalf("resolveLinks: %s", err)
+	}
+	if len(got) != 1 || got[0] != "abc123:redis" {
+		t.Errorf("got %v, want [abc123:redis]", got)
+	}
+
+	got, err = resolveLinks([]*string{aws.String("redis:cache")}, running)
+	if err != nil {
+		t.Fatalf("resolveLinks: %s", err)
+	}
+	if len(got) != 1 || got[0] != "abc123:cache" {
+		t.Errorf("got %v, want [abc123:cache]", got)
+	}
+}
+
+func TestResolveLinksNotRunningYet(t *testing.T) {
+	if _, err := resolveLinks([]*string{aws.String("redis")}, map[string]*runningContainer{}); err == nil {
+		t.Fatal("expected an error for a link to a container that isn't running yet")
+	}
+}
+
+func TestResolveVolumesFrom(t *testing.T) {
+	running := map[string]*runningContainer{
+		"data": {name: "data", id: "def456"},
+	}
+
+	got, err := resolveVolumesFrom([]*ecs.VolumeFrom{{SourceContainer: aws.String("data")}}, running)
+	if err != nil {
+		t.Fatalf("resolveVolumesFrom: %s", err)
+	}
+	if len(got) != 1 || got[0] != "def456" {
+		t.Errorf("got %v, want [def456]", got)
+	}
+
+	got, err = resolveVolumesFrom([]*ecs.VolumeFrom{{SourceContainer: aws.String("data"), ReadOnly: aws.Bool(true)}}, running)
+	if err != nil {
+		t.Fatalf("resolveVolumesFrom: %s", err)
+	}
+	if len(got) != 1 || got[0] != "def456:ro" {
+		t.Errorf("got %v, want [def456:ro]", got)
+	}
+}
+
+func TestResolveVolumesFromNotRunningYet(t *testing.T) {
+	_, err := resolveVolumesFrom([]*ecs.VolumeFrom{{SourceContainer: aws.String("data")}}, map[string]*runningContainer{})
+	if err == nil {
+		t.Fatal("expected an error for a volumesFrom container that isn't running yet")
+	}
+}
+
+func TestContainerConfigResolvesLinksAndVolumesFrom(t *testing.T) {
+	cd := &ecs.ContainerDefinition{
+		Image:       aws.String("app:latest"),
+		Links:       []*string{aws.String("redis")},
+		VolumesFrom: []*ecs.VolumeFrom{{SourceContainer: aws.String("data")}},
+	}
+	running := map[string]*runningContainer{
+		"redis": {name: "redis", id: "abc123"},
+		"data":  {name: "data", id: "def456"},
+	}
+
+	_, hostConfig, err := containerConfig(cd, "ecs-local-net", nil, nil, running)
+	if err != nil {
+		t.Fatalf("containerConfig: %s", err)
+	}
+	if len(hostConfig.Links) != 1 || hostConfig.Links[0] != "abc123:redis" {
+		t.Errorf("Links = %v", hostConfig.Links)
+	}
+	if len(hostConfig.VolumesFrom) != 1 || hostConfig.VolumesFrom[0] != "def456" {
+		t.Errorf("VolumesFrom = %v", hostConfig.VolumesFrom)
+	}
+}
+
+func TestContainerConfigLinkNotRunningYet(t *testing.T) {
+	cd := &ecs.ContainerDefinition{
+		Image: aws.String("app:latest"),
+		Links: []*string{aws.String("redis")},
+	}
+	if _, _, err := containerConfig(cd, "ecs-local-net", nil, nil, map[string]*runningContainer{}); err == nil {
+		t.Fatal("expected an error for a link to a container that isn't running yet")
+	}
+}